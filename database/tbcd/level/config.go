@@ -0,0 +1,107 @@
+// Copyright (c) 2025 Hemi Labs, Inc.
+// Use of this source code is governed by the MIT License,
+// which can be found in the LICENSE file.
+
+package level
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultBlockCacheSize  = 32 * 1024 * 1024
+	defaultWriteBufferSize = 16 * 1024 * 1024
+
+	// defaultStreamBatchBytes and defaultStreamBatchRows bound how much the
+	// MetadataStream*/BlockKeystonesStreamUpdate APIs buffer in memory
+	// before flushing a leveldb batch; whichever limit is hit first wins.
+	defaultStreamBatchBytes = 4 * 1024 * 1024
+	defaultStreamBatchRows  = 10_000
+)
+
+// Config is the configuration for a level.Database.
+type Config struct {
+	Network string
+	Home    string
+
+	BlockCacheSize  int
+	WriteBufferSize int
+
+	// StreamBatchBytes and StreamBatchRows bound the size of the leveldb
+	// batches the streaming APIs coalesce writes into. Callers that need
+	// non-default values can set these fields directly after NewConfig
+	// returns.
+	StreamBatchBytes int
+	StreamBatchRows  int
+}
+
+// NewConfig returns a Config for the given network and home directory.
+// blockCacheSize and writeBufferSize accept human readable byte sizes such
+// as "128kb" or "1m"; an empty string selects the package default.
+func NewConfig(network, home, blockCacheSize, writeBufferSize string) (*Config, error) {
+	if network == "" {
+		return nil, fmt.Errorf("network must be set")
+	}
+	if home == "" {
+		return nil, fmt.Errorf("home must be set")
+	}
+
+	bcs := defaultBlockCacheSize
+	if blockCacheSize != "" {
+		v, err := parseByteSize(blockCacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("block cache size: %w", err)
+		}
+		bcs = v
+	}
+
+	wbs := defaultWriteBufferSize
+	if writeBufferSize != "" {
+		v, err := parseByteSize(writeBufferSize)
+		if err != nil {
+			return nil, fmt.Errorf("write buffer size: %w", err)
+		}
+		wbs = v
+	}
+
+	return &Config{
+		Network:          network,
+		Home:             home,
+		BlockCacheSize:   bcs,
+		WriteBufferSize:  wbs,
+		StreamBatchBytes: defaultStreamBatchBytes,
+		StreamBatchRows:  defaultStreamBatchRows,
+	}, nil
+}
+
+// parseByteSize parses human readable byte sizes such as "128kb" or "1m"
+// into their integer byte count.
+func parseByteSize(s string) (int, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+
+	mult := 1
+	switch {
+	case strings.HasSuffix(s, "gb"):
+		mult, s = 1024*1024*1024, strings.TrimSuffix(s, "gb")
+	case strings.HasSuffix(s, "g"):
+		mult, s = 1024*1024*1024, strings.TrimSuffix(s, "g")
+	case strings.HasSuffix(s, "mb"):
+		mult, s = 1024*1024, strings.TrimSuffix(s, "mb")
+	case strings.HasSuffix(s, "m"):
+		mult, s = 1024*1024, strings.TrimSuffix(s, "m")
+	case strings.HasSuffix(s, "kb"):
+		mult, s = 1024, strings.TrimSuffix(s, "kb")
+	case strings.HasSuffix(s, "k"):
+		mult, s = 1024, strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "b"):
+		s = strings.TrimSuffix(s, "b")
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	return n * mult, nil
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2025 Hemi Labs, Inc.
+// Use of this source code is governed by the MIT License,
+// which can be found in the LICENSE file.
+
+package level
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hemilabs/heminetwork/database/tbcd"
+)
+
+// DebugOpts configures NewDebugDB.
+type DebugOpts struct {
+	// SlowThreshold is the duration above which an operation is logged.
+	// Zero disables slow-op logging.
+	SlowThreshold time.Duration
+
+	// Logger receives slow-op log lines. Defaults to slog.Default.
+	Logger *slog.Logger
+}
+
+// OpStats is a point-in-time snapshot of the call and byte counters for a
+// single operation.
+type OpStats struct {
+	Calls uint64
+	Bytes uint64
+}
+
+// Stats is a point-in-time snapshot returned by debugDB.Stats.
+type Stats struct {
+	MetadataGet      OpStats
+	MetadataPut      OpStats
+	MetadataBatchGet OpStats
+	MetadataBatchPut OpStats
+	KeystoneWind     OpStats
+	KeystoneUnwind   OpStats
+	KeystoneLookup   OpStats
+}
+
+// opCounter is a concurrency-safe call/byte counter for one operation.
+type opCounter struct {
+	calls uint64
+	bytes uint64
+}
+
+func (c *opCounter) add(n int) {
+	atomic.AddUint64(&c.calls, 1)
+	atomic.AddUint64(&c.bytes, uint64(n))
+}
+
+func (c *opCounter) snapshot() OpStats {
+	return OpStats{
+		Calls: atomic.LoadUint64(&c.calls),
+		Bytes: atomic.LoadUint64(&c.bytes),
+	}
+}
+
+// debugDB decorates a tbcd.Database with per-operation call/byte counters,
+// slow-op logging, and Prometheus collectors. It adds negligible overhead
+// when SlowThreshold is zero: the hot path is an atomic add and a
+// Prometheus observation, and the key description passed to track is only
+// computed when a slow operation is actually about to be logged, so no
+// per-call key string is built while logging is off or the call is fast.
+//
+// tbcd.Database is embedded so that any method this decorator does not
+// explicitly instrument (and any future additions to the interface) still
+// forward straight through to inner.
+type debugDB struct {
+	tbcd.Database
+	opts DebugOpts
+
+	metadataGet      opCounter
+	metadataPut      opCounter
+	metadataBatchGet opCounter
+	metadataBatchPut opCounter
+	keystoneWind     opCounter
+	keystoneUnwind   opCounter
+	keystoneLookup   opCounter
+
+	duration *prometheus.HistogramVec
+}
+
+// NewDebugDB wraps inner with per-operation counters and slow-op logging.
+// The returned Database forwards every call to inner unchanged; it only
+// observes.
+func NewDebugDB(inner tbcd.Database, opts DebugOpts) tbcd.Database {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &debugDB{
+		Database: inner,
+		opts:     opts,
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "tbcd",
+			Subsystem: "level",
+			Name:      "op_duration_seconds",
+			Help:      "Duration of level.Database operations, labeled by operation name.",
+		}, []string{"op"}),
+	}
+}
+
+// Stats returns a point-in-time snapshot of every operation counter.
+func (db *debugDB) Stats() Stats {
+	return Stats{
+		MetadataGet:      db.metadataGet.snapshot(),
+		MetadataPut:      db.metadataPut.snapshot(),
+		MetadataBatchGet: db.metadataBatchGet.snapshot(),
+		MetadataBatchPut: db.metadataBatchPut.snapshot(),
+		KeystoneWind:     db.keystoneWind.snapshot(),
+		KeystoneUnwind:   db.keystoneUnwind.snapshot(),
+		KeystoneLookup:   db.keystoneLookup.snapshot(),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (db *debugDB) Describe(ch chan<- *prometheus.Desc) {
+	db.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (db *debugDB) Collect(ch chan<- prometheus.Metric) {
+	db.duration.Collect(ch)
+}
+
+// track runs fn, updates c and the duration histogram, and logs a slow-op
+// warning if elapsed exceeds SlowThreshold. key is only called (to build
+// the logged key description) once a slow operation is confirmed, so
+// callers can pass a closure that allocates without paying for it on the
+// hot path.
+func (db *debugDB) track(op string, c *opCounter, n int, key func() string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+
+	c.add(n)
+	db.duration.WithLabelValues(op).Observe(elapsed.Seconds())
+
+	if db.opts.SlowThreshold > 0 && elapsed > db.opts.SlowThreshold {
+		db.opts.Logger.Warn("slow tbcd operation",
+			"op", op, "duration", elapsed, "key", key(), "error", err)
+	}
+	return err
+}
+
+func (db *debugDB) MetadataGet(ctx context.Context, key []byte) ([]byte, error) {
+	var value []byte
+	err := db.track("MetadataGet", &db.metadataGet, len(key), func() string { return string(key) }, func() error {
+		v, err := db.Database.MetadataGet(ctx, key)
+		value = v
+		return err
+	})
+	return value, err
+}
+
+func (db *debugDB) MetadataPut(ctx context.Context, key, value []byte) error {
+	return db.track("MetadataPut", &db.metadataPut, len(key)+len(value), func() string { return string(key) }, func() error {
+		return db.Database.MetadataPut(ctx, key, value)
+	})
+}
+
+func (db *debugDB) MetadataBatchGet(ctx context.Context, allOrNothing bool, keys [][]byte) ([]tbcd.Row, error) {
+	var rows []tbcd.Row
+	n := 0
+	for _, key := range keys {
+		n += len(key)
+	}
+	err := db.track("MetadataBatchGet", &db.metadataBatchGet, n, func() string { return fmt.Sprintf("%d keys", len(keys)) }, func() error {
+		r, err := db.Database.MetadataBatchGet(ctx, allOrNothing, keys)
+		rows = r
+		return err
+	})
+	return rows, err
+}
+
+func (db *debugDB) MetadataBatchPut(ctx context.Context, rows []tbcd.Row) error {
+	n := 0
+	for _, row := range rows {
+		n += len(row.Key) + len(row.Value)
+	}
+	return db.track("MetadataBatchPut", &db.metadataBatchPut, n, func() string { return fmt.Sprintf("%d rows", len(rows)) }, func() error {
+		return db.Database.MetadataBatchPut(ctx, rows)
+	})
+}
+
+func (db *debugDB) BlockKeystoneUpdate(ctx context.Context, direction int, kssMap map[chainhash.Hash]tbcd.Keystone, blockhash chainhash.Hash) error {
+	op, c := "BlockKeystoneUpdate(wind)", &db.keystoneWind
+	if direction == -1 {
+		op, c = "BlockKeystoneUpdate(unwind)", &db.keystoneUnwind
+	}
+	return db.track(op, c, len(kssMap), blockhash.String, func() error {
+		return db.Database.BlockKeystoneUpdate(ctx, direction, kssMap, blockhash)
+	})
+}
+
+func (db *debugDB) BlockKeystoneByL2KeystoneAbrevHash(ctx context.Context, abrvHash chainhash.Hash) (*tbcd.Keystone, error) {
+	var ks *tbcd.Keystone
+	err := db.track("BlockKeystoneByL2KeystoneAbrevHash", &db.keystoneLookup, 1, abrvHash.String, func() error {
+		k, err := db.Database.BlockKeystoneByL2KeystoneAbrevHash(ctx, abrvHash)
+		ks = k
+		return err
+	})
+	return ks, err
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2025 Hemi Labs, Inc.
+// Use of this source code is governed by the MIT License,
+// which can be found in the LICENSE file.
+
+package level
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/hemilabs/heminetwork/database/tbcd"
+)
+
+// blockIndexPrefix tags secondary-index rows (blockhash -> []abrvHash); see
+// keystonePrimaryPrefix in keystone.go for the primary rows' prefix.
+const blockIndexPrefix = 0xff
+
+// blockIndexKey returns the secondary-index key for blockhash, under which
+// the set of abbreviated keystone hashes that reference it is stored.
+func blockIndexKey(blockhash chainhash.Hash) []byte {
+	key := make([]byte, 0, 1+chainhash.HashSize)
+	key = append(key, blockIndexPrefix)
+	key = append(key, blockhash[:]...)
+	return key
+}
+
+func encodeBlockIndex(hashes []chainhash.Hash) []byte {
+	b := make([]byte, 0, len(hashes)*chainhash.HashSize)
+	for _, h := range hashes {
+		b = append(b, h[:]...)
+	}
+	return b
+}
+
+func decodeBlockIndex(b []byte) []chainhash.Hash {
+	hashes := make([]chainhash.Hash, 0, len(b)/chainhash.HashSize)
+	for len(b) >= chainhash.HashSize {
+		var h chainhash.Hash
+		copy(h[:], b[:chainhash.HashSize])
+		hashes = append(hashes, h)
+		b = b[chainhash.HashSize:]
+	}
+	return hashes
+}
+
+// blockIndex is an in-memory, mutable view of the secondary index for one
+// blockhash. Every keystone wound or unwound on behalf of the same
+// blockhash within a single call shares one blockIndex so that the index
+// row is read once and rewritten once, rather than read-modify-written
+// per keystone (which would make all but the last update in a batch
+// clobber the others).
+type blockIndex struct {
+	blockhash chainhash.Hash
+	set       map[chainhash.Hash]struct{}
+}
+
+// loadBlockIndex reads the current secondary index for blockhash.
+func loadBlockIndex(g levelGetter, blockhash chainhash.Hash) (*blockIndex, error) {
+	existing, err := g.Get(blockIndexKey(blockhash), nil)
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		return nil, err
+	}
+	hashes := decodeBlockIndex(existing)
+	set := make(map[chainhash.Hash]struct{}, len(hashes))
+	for _, h := range hashes {
+		set[h] = struct{}{}
+	}
+	return &blockIndex{blockhash: blockhash, set: set}, nil
+}
+
+// add and remove mutate the in-memory index; call flush to persist.
+func (bi *blockIndex) add(abrvHash chainhash.Hash)    { bi.set[abrvHash] = struct{}{} }
+func (bi *blockIndex) remove(abrvHash chainhash.Hash) { delete(bi.set, abrvHash) }
+
+// flush writes the current state of the index into batch as a single row.
+func (bi *blockIndex) flush(batch *leveldb.Batch) {
+	key := blockIndexKey(bi.blockhash)
+	if len(bi.set) == 0 {
+		batch.Delete(key)
+		return
+	}
+	hashes := make([]chainhash.Hash, 0, len(bi.set))
+	for h := range bi.set {
+		hashes = append(hashes, h)
+	}
+	batch.Put(key, encodeBlockIndex(hashes))
+}
+
+// KeystonesByBlockHash returns the keystones referenced by blockhash, as
+// maintained by the secondary index BlockKeystoneUpdate keeps up to date on
+// every wind and unwind.
+func (d *Database) KeystonesByBlockHash(ctx context.Context, blockhash chainhash.Hash) ([]tbcd.Keystone, error) {
+	return keystonesByBlockHash(d.keystoneDB, blockhash)
+}
+
+func keystonesByBlockHash(g levelGetter, blockhash chainhash.Hash) ([]tbcd.Keystone, error) {
+	value, err := g.Get(blockIndexKey(blockhash), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	abrvHashes := decodeBlockIndex(value)
+	kss := make([]tbcd.Keystone, 0, len(abrvHashes))
+	for _, abrvHash := range abrvHashes {
+		ks, err := keystoneByL2KeystoneAbrevHash(g, abrvHash)
+		if err != nil {
+			return nil, fmt.Errorf("keystone %v for block %v: %w", abrvHash, blockhash, err)
+		}
+		kss = append(kss, *ks)
+	}
+	return kss, nil
+}
+
+// KeystoneIterator iterates over the primary abrvHash -> tbcd.Keystone
+// keyspace. It is backed by a goleveldb snapshot taken when the iterator is
+// created, so concurrent writes (e.g. a wind or unwind) are never observed
+// mid-iteration; callers see the database exactly as it was at the moment
+// KeystoneIterator was called. The iterator must be closed with Close once
+// the caller is done with it.
+type KeystoneIterator struct {
+	snap *leveldb.Snapshot
+	iter leveldbIterator
+}
+
+// leveldbIterator is the subset of goleveldb's iterator.Iterator that
+// KeystoneIterator wraps.
+type leveldbIterator interface {
+	Seek(key []byte) bool
+	Next() bool
+	Prev() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// KeystoneIterator returns a forward/reverse iterator over the primary
+// keystone keyspace between start and end, both inclusive. A nil start or
+// end leaves that side of the range unbounded.
+func (d *Database) KeystoneIterator(ctx context.Context, start, end *chainhash.Hash) (*KeystoneIterator, error) {
+	snap, err := d.keystoneDB.GetSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("keystone iterator snapshot: %w", err)
+	}
+
+	// Scope the scan to the primary keyspace (prefix keystonePrimaryPrefix)
+	// so the blockhash secondary index rows are never observed.
+	slice := *util.BytesPrefix([]byte{keystonePrimaryPrefix})
+	if start != nil {
+		slice.Start = primaryKey(*start)
+	}
+	if end != nil {
+		// util.Range.Limit is exclusive; KeystoneIterator's start/end are
+		// documented as inclusive of end, so bump the limit past end to
+		// include it.
+		slice.Limit = append(primaryKey(*end), 0x00)
+	}
+
+	iter := snap.NewIterator(&slice, nil)
+	return &KeystoneIterator{snap: snap, iter: iter}, nil
+}
+
+// Seek moves the iterator to the first key >= key and reports whether such
+// a key exists.
+func (k *KeystoneIterator) Seek(key chainhash.Hash) bool {
+	return k.iter.Seek(primaryKey(key))
+}
+
+// Next advances the iterator and reports whether a next entry exists.
+func (k *KeystoneIterator) Next() bool {
+	return k.iter.Next()
+}
+
+// Prev moves the iterator backward and reports whether a previous entry
+// exists.
+func (k *KeystoneIterator) Prev() bool {
+	return k.iter.Prev()
+}
+
+// Key returns the abbreviated keystone hash the iterator currently points
+// at. It returns the zero hash if the iterator is not positioned on a valid
+// entry (e.g. Key is called before a Seek/Next/Prev that returned true, or
+// after one that returned false).
+func (k *KeystoneIterator) Key() chainhash.Hash {
+	var h chainhash.Hash
+	key := k.iter.Key()
+	if len(key) == 0 {
+		return h
+	}
+	copy(h[:], key[1:]) // strip keystonePrimaryPrefix
+	return h
+}
+
+// Value returns the keystone the iterator currently points at. It returns
+// the zero Keystone if the iterator is not positioned on a valid entry; see
+// Key.
+func (k *KeystoneIterator) Value() tbcd.Keystone {
+	value := k.iter.Value()
+	if len(value) == 0 {
+		return tbcd.Keystone{}
+	}
+	return decodeKeystone(value)
+}
+
+// Error returns the first error, if any, encountered during iteration.
+func (k *KeystoneIterator) Error() error {
+	return k.iter.Error()
+}
+
+// Close releases the iterator and its backing snapshot. The iterator must
+// not be used after Close is called.
+func (k *KeystoneIterator) Close() {
+	k.iter.Release()
+	k.snap.Release()
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2025 Hemi Labs, Inc.
+// Use of this source code is governed by the MIT License,
+// which can be found in the LICENSE file.
+
+package level
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+
+	"github.com/hemilabs/heminetwork/database"
+	"github.com/hemilabs/heminetwork/database/tbcd"
+)
+
+// levelReadWriter is satisfied by *leveldb.DB and *leveldb.Transaction,
+// which lets blockKeystoneUpdate serve both a direct database write and a
+// write performed inside a Tx.
+type levelReadWriter interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	Write(batch *leveldb.Batch, wo *opt.WriteOptions) error
+}
+
+// Keys in the keystone database are tagged with a one byte prefix so that
+// the primary abrvHash -> tbcd.Keystone rows and the blockhash -> []abrvHash
+// secondary index rows (see iterator.go) occupy disjoint, independently
+// scannable key ranges.
+const keystonePrimaryPrefix = 0x00
+
+// primaryKey returns the primary-row key for an abbreviated keystone hash.
+func primaryKey(abrvHash chainhash.Hash) []byte {
+	key := make([]byte, 0, 1+chainhash.HashSize)
+	key = append(key, keystonePrimaryPrefix)
+	return append(key, abrvHash[:]...)
+}
+
+// encodeKeystoneToSlice encodes a tbcd.Keystone into its on-disk
+// representation: the 32 byte block hash followed by the abbreviated
+// keystone bytes.
+func encodeKeystoneToSlice(ks tbcd.Keystone) []byte {
+	b := make([]byte, chainhash.HashSize+len(ks.AbbreviatedKeystone))
+	copy(b[0:chainhash.HashSize], ks.BlockHash[:])
+	copy(b[chainhash.HashSize:], ks.AbbreviatedKeystone)
+	return b
+}
+
+// decodeKeystone reverses encodeKeystoneToSlice.
+func decodeKeystone(b []byte) tbcd.Keystone {
+	var ks tbcd.Keystone
+	copy(ks.BlockHash[:], b[0:chainhash.HashSize])
+	ks.AbbreviatedKeystone = append([]byte(nil), b[chainhash.HashSize:]...)
+	return ks
+}
+
+func keystoneByL2KeystoneAbrevHash(g levelGetter, abrvHash chainhash.Hash) (*tbcd.Keystone, error) {
+	value, err := g.Get(primaryKey(abrvHash), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("keystone %v: %w", abrvHash, database.ErrNotFound)
+		}
+		return nil, err
+	}
+	ks := decodeKeystone(value)
+	return &ks, nil
+}
+
+// blockKeystoneUpdate winds (direction 1) or unwinds (direction -1) the
+// keystones in kssMap as a single leveldb batch write against kdb.
+//
+// Winding always (re)writes the keystone, making repeated winds of the same
+// keystone idempotent. Unwinding is a compare-and-delete: a keystone is only
+// removed if the value currently on disk matches the caller's view of it
+// exactly, so an unwind call built from stale or mismatched data is a no-op
+// rather than a destructive surprise.
+func blockKeystoneUpdate(kdb levelReadWriter, direction int, kssMap map[chainhash.Hash]tbcd.Keystone, blockhash chainhash.Hash) error {
+	if direction != 1 && direction != -1 {
+		return fmt.Errorf("invalid direction: %v", direction)
+	}
+
+	bi, err := loadBlockIndex(kdb, blockhash)
+	if err != nil {
+		return fmt.Errorf("load block index: %w", err)
+	}
+
+	batch := new(leveldb.Batch)
+	for abrvHash, ks := range kssMap {
+		key := primaryKey(abrvHash)
+		switch direction {
+		case 1:
+			batch.Put(key, encodeKeystoneToSlice(ks))
+			bi.add(abrvHash)
+		case -1:
+			existing, err := kdb.Get(key, nil)
+			if err != nil {
+				if errors.Is(err, leveldb.ErrNotFound) {
+					continue
+				}
+				return err
+			}
+			if stored := decodeKeystone(existing); !reflect.DeepEqual(stored, ks) {
+				continue
+			}
+			batch.Delete(key)
+			bi.remove(abrvHash)
+		}
+	}
+	bi.flush(batch)
+	return kdb.Write(batch, nil)
+}
+
+// BlockKeystoneUpdate winds (direction 1) or unwinds (direction -1) kssMap
+// against the keystone database. blockhash identifies the L1 block the
+// update is being performed on behalf of.
+func (d *Database) BlockKeystoneUpdate(ctx context.Context, direction int, kssMap map[chainhash.Hash]tbcd.Keystone, blockhash chainhash.Hash) error {
+	return blockKeystoneUpdate(d.keystoneDB, direction, kssMap, blockhash)
+}
+
+// BlockKeystoneByL2KeystoneAbrevHash returns the keystone stored under the
+// given abbreviated L2 keystone hash.
+func (d *Database) BlockKeystoneByL2KeystoneAbrevHash(ctx context.Context, abrvHash chainhash.Hash) (*tbcd.Keystone, error) {
+	return keystoneByL2KeystoneAbrevHash(d.keystoneDB, abrvHash)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2025 Hemi Labs, Inc.
+// Use of this source code is governed by the MIT License,
+// which can be found in the LICENSE file.
+
+// Package level implements a tbcd.Database backed by goleveldb.
+package level
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+const (
+	metadataDBName = "metadata"
+	keystoneDBName = "keystones"
+)
+
+// Database is a tbcd.Database implementation backed by a set of goleveldb
+// databases rooted under cfg.Home/cfg.Network.
+type Database struct {
+	mtx sync.RWMutex
+	cfg *Config
+
+	metadataDB *leveldb.DB
+	keystoneDB *leveldb.DB
+}
+
+// New opens, and if necessary creates, the on-disk databases described by
+// cfg.
+func New(ctx context.Context, cfg *Config) (*Database, error) {
+	if cfg == nil {
+		return nil, errors.New("config must be set")
+	}
+
+	o := &opt.Options{
+		Filter:             filter.NewBloomFilter(10),
+		BlockCacheCapacity: cfg.BlockCacheSize,
+		WriteBuffer:        cfg.WriteBufferSize,
+	}
+
+	home := filepath.Join(cfg.Home, cfg.Network)
+
+	metadataDB, err := leveldb.OpenFile(filepath.Join(home, metadataDBName), o)
+	if err != nil {
+		return nil, fmt.Errorf("open metadata db: %w", err)
+	}
+
+	keystoneDB, err := leveldb.OpenFile(filepath.Join(home, keystoneDBName), o)
+	if err != nil {
+		metadataDB.Close()
+		return nil, fmt.Errorf("open keystone db: %w", err)
+	}
+
+	return &Database{
+		cfg:        cfg,
+		metadataDB: metadataDB,
+		keystoneDB: keystoneDB,
+	}, nil
+}
+
+// Close closes all underlying databases. Database must not be used after
+// Close is called.
+func (d *Database) Close() error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var errs []error
+	if err := d.metadataDB.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close metadata db: %w", err))
+	}
+	if err := d.keystoneDB.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close keystone db: %w", err))
+	}
+	return errors.Join(errs...)
+}
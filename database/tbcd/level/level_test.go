@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"maps"
 	"reflect"
+	"runtime"
 	"testing"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -525,3 +526,719 @@ func TestKeystoneDBCache(t *testing.T) {
 		}
 	}
 }
+
+// TestKeystoneDBSnapshotDuringUnwind proves that a Snapshot taken before an
+// unwind continues to observe the pre-unwind keystones even after the
+// unwind commits, and that the live database reflects the unwind as usual.
+func TestKeystoneDBSnapshotDuringUnwind(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	home := t.TempDir()
+	t.Logf("temp: %v", home)
+
+	cfg, err := NewConfig("testnet3", home, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	blockhash := chainhash.Hash{0xca, 0xfe}
+	blkHash := chainhash.Hash{1}
+	k1hash, k1 := newKeystone(&blkHash, 1, 2)
+	ksm := map[chainhash.Hash]tbcd.Keystone{*k1hash: k1}
+
+	if err := db.BlockKeystoneUpdate(ctx, 1, maps.Clone(ksm), blockhash); err != nil {
+		t.Fatal(err)
+	}
+
+	// Take a snapshot before the unwind.
+	snap, err := db.Snapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+
+	if err := db.BlockKeystoneUpdate(ctx, -1, maps.Clone(ksm), blockhash); err != nil {
+		t.Fatal(err)
+	}
+
+	// The live database no longer has the keystone.
+	if _, err := db.BlockKeystoneByL2KeystoneAbrevHash(ctx, *k1hash); !errors.Is(err, database.ErrNotFound) {
+		t.Fatalf("expected '%v', got '%v'", database.ErrNotFound, err)
+	}
+
+	// The snapshot, taken before the unwind, still does.
+	sks, err := snap.BlockKeystoneByL2KeystoneAbrevHash(ctx, *k1hash)
+	if err != nil {
+		t.Fatalf("keystone missing from snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(k1, *sks) {
+		t.Fatalf("%v%v", spew.Sdump(k1), spew.Sdump(*sks))
+	}
+}
+
+// TestKeystoneDBTransaction proves that BlockKeystoneUpdate performed
+// through a Tx is invisible until Commit, and that Rollback discards it
+// entirely.
+func TestKeystoneDBTransaction(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	home := t.TempDir()
+	t.Logf("temp: %v", home)
+
+	cfg, err := NewConfig("testnet3", home, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	blockhash := chainhash.Hash{0xbe, 0xef}
+	blkHash := chainhash.Hash{1}
+	k1hash, k1 := newKeystone(&blkHash, 1, 2)
+	ksm := map[chainhash.Hash]tbcd.Keystone{*k1hash: k1}
+
+	// Rolled-back transaction must leave no trace.
+	tx, err := db.Transaction(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.BlockKeystoneUpdate(ctx, 1, maps.Clone(ksm), blockhash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.BlockKeystoneByL2KeystoneAbrevHash(ctx, *k1hash); err != nil {
+		t.Fatalf("keystone not visible inside tx: %v", err)
+	}
+	tx.Rollback()
+	if _, err := db.BlockKeystoneByL2KeystoneAbrevHash(ctx, *k1hash); !errors.Is(err, database.ErrNotFound) {
+		t.Fatalf("expected '%v', got '%v'", database.ErrNotFound, err)
+	}
+
+	// Committed transaction must be visible afterwards.
+	tx, err = db.Transaction(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.BlockKeystoneUpdate(ctx, 1, maps.Clone(ksm), blockhash); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	dks, err := db.BlockKeystoneByL2KeystoneAbrevHash(ctx, *k1hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(k1, *dks) {
+		t.Fatalf("%v%v", spew.Sdump(k1), spew.Sdump(*dks))
+	}
+}
+
+// TestKeystoneDBIterator walks all keystones between two hashes with
+// KeystoneIterator and verifies that unwinding them removes them both from
+// the primary store and from the blockhash secondary index.
+func TestKeystoneDBIterator(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	home := t.TempDir()
+	t.Logf("temp: %v", home)
+
+	cfg, err := NewConfig("testnet3", home, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	blockhash := chainhash.Hash{0x13, 0x37}
+	blkHash := chainhash.Hash{1}
+	k1hash, k1 := newKeystone(&blkHash, 1, 2)
+	k2hash, k2 := newKeystone(&blkHash, 2, 3)
+	ksm := map[chainhash.Hash]tbcd.Keystone{*k1hash: k1, *k2hash: k2}
+
+	if err := db.BlockKeystoneUpdate(ctx, 1, maps.Clone(ksm), blockhash); err != nil {
+		t.Fatal(err)
+	}
+
+	// Secondary index must list both keystones for blockhash.
+	kss, err := db.KeystonesByBlockHash(ctx, blockhash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kss) != 2 {
+		t.Fatalf("expected 2 keystones, got %d: %v", len(kss), spew.Sdump(kss))
+	}
+
+	// Walk the keyspace between the two keystone hashes, both inclusive,
+	// and make sure both keystones are observed exactly once.
+	lo, hi := k1hash, k2hash
+	if bytes.Compare(lo[:], hi[:]) > 0 {
+		lo, hi = hi, lo
+	}
+	iter, err := db.KeystoneIterator(ctx, lo, hi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[chainhash.Hash]tbcd.Keystone)
+	for iter.Next() {
+		seen[iter.Key()] = iter.Value()
+	}
+	if err := iter.Error(); err != nil {
+		t.Fatal(err)
+	}
+	iter.Close()
+	if diff := deep.Equal(seen, ksm); len(diff) > 0 {
+		t.Fatalf("iterator mismatch: %s", diff)
+	}
+
+	// Unwind and confirm both the primary store and the secondary index are
+	// cleared.
+	if err := db.BlockKeystoneUpdate(ctx, -1, maps.Clone(ksm), blockhash); err != nil {
+		t.Fatal(err)
+	}
+	kss, err = db.KeystonesByBlockHash(ctx, blockhash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kss) != 0 {
+		t.Fatalf("expected empty block index, got %v", spew.Sdump(kss))
+	}
+
+	iter, err = db.KeystoneIterator(ctx, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iter.Close()
+	if iter.Next() {
+		t.Fatalf("expected empty keystone db, got key %v", iter.Key())
+	}
+}
+
+// TestMetadataPrefix confirms that two MetadataPrefix handles over the same
+// database cannot see or collide with each other's keys, even when the
+// unprefixed keys are identical.
+func TestMetadataPrefix(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	home := t.TempDir()
+	t.Logf("temp: %v", home)
+
+	cfg, err := NewConfig("testnet3", home, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	a := db.MetadataPrefix([]byte("consensus/"))
+	b := db.MetadataPrefix([]byte("keystones/"))
+
+	key := []byte("cursor")
+	if err := a.MetadataPut(ctx, key, []byte("a-value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.MetadataPut(ctx, key, []byte("b-value")); err != nil {
+		t.Fatal(err)
+	}
+
+	av, err := a.MetadataGet(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(av, []byte("a-value")) {
+		t.Fatalf("got %s, expected a-value", av)
+	}
+
+	bv, err := b.MetadataGet(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bv, []byte("b-value")) {
+		t.Fatalf("got %s, expected b-value", bv)
+	}
+
+	// A key only ever written in namespace b must be invisible from a.
+	if err := b.MetadataPut(ctx, []byte("only-in-b"), []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.MetadataGet(ctx, []byte("only-in-b")); !errors.Is(err, database.ErrNotFound) {
+		t.Fatalf("expected '%v', got '%v'", database.ErrNotFound, err)
+	}
+
+	// Batch get must report the caller-visible key in per-row errors, not
+	// the internally prefixed one.
+	rows, err := a.MetadataBatchGet(ctx, false, [][]byte{key, []byte("only-in-b")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rows[0].Key, key) || rows[0].Error != nil {
+		t.Fatalf("unexpected row 0: %v", spew.Sdump(rows[0]))
+	}
+	if !bytes.Equal(rows[1].Key, []byte("only-in-b")) || !errors.Is(rows[1].Error, database.ErrNotFound) {
+		t.Fatalf("unexpected row 1: %v", spew.Sdump(rows[1]))
+	}
+
+	// The underlying, unprefixed database must not see either namespaced
+	// key directly.
+	if _, err := db.MetadataGet(ctx, key); !errors.Is(err, database.ErrNotFound) {
+		t.Fatalf("expected '%v', got '%v'", database.ErrNotFound, err)
+	}
+}
+
+// TestDebugDB confirms that NewDebugDB's counters increment as expected
+// across a metadata write/read and a keystone wind/unwind cycle, and that
+// it otherwise behaves identically to the database it wraps.
+func TestDebugDB(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	home := t.TempDir()
+	t.Logf("temp: %v", home)
+
+	cfg, err := NewConfig("testnet3", home, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := inner.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	ddb, ok := NewDebugDB(inner, DebugOpts{}).(*debugDB)
+	if !ok {
+		t.Fatal("NewDebugDB did not return *debugDB")
+	}
+
+	if err := ddb.MetadataPut(ctx, []byte("k"), []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ddb.MetadataGet(ctx, []byte("k")); err != nil {
+		t.Fatal(err)
+	}
+
+	blockhash := chainhash.Hash{0x42}
+	blkHash := chainhash.Hash{1}
+	k1hash, k1 := newKeystone(&blkHash, 1, 2)
+	ksm := map[chainhash.Hash]tbcd.Keystone{*k1hash: k1}
+	if err := ddb.BlockKeystoneUpdate(ctx, 1, maps.Clone(ksm), blockhash); err != nil {
+		t.Fatal(err)
+	}
+	if err := ddb.BlockKeystoneUpdate(ctx, -1, maps.Clone(ksm), blockhash); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := ddb.Stats()
+	if stats.MetadataPut.Calls != 1 {
+		t.Fatalf("expected 1 MetadataPut call, got %d", stats.MetadataPut.Calls)
+	}
+	if stats.MetadataGet.Calls != 1 {
+		t.Fatalf("expected 1 MetadataGet call, got %d", stats.MetadataGet.Calls)
+	}
+	if stats.KeystoneWind.Calls != 1 {
+		t.Fatalf("expected 1 wind call, got %d", stats.KeystoneWind.Calls)
+	}
+	if stats.KeystoneUnwind.Calls != 1 {
+		t.Fatalf("expected 1 unwind call, got %d", stats.KeystoneUnwind.Calls)
+	}
+}
+
+// TestMetadataStream pushes a large number of rows through
+// MetadataStreamPut/MetadataStreamGet with a small StreamBatchRows so that
+// many batch flushes occur, and verifies every row round-trips correctly.
+// The row count is chosen to be large enough to force many flushes while
+// keeping the test fast; BlockKeystonesStreamUpdate's bulk-import use case
+// is the same code path at larger scale.
+func TestMetadataStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	home := t.TempDir()
+	t.Logf("temp: %v", home)
+
+	cfg, err := NewConfig("testnet3", home, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.StreamBatchRows = 16 // force many flushes within a small test
+	db, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	const rowCount = 5000
+	want := make(map[string][]byte, rowCount)
+	for i := 0; i < rowCount; i++ {
+		key := fmt.Appendf(nil, "key-%d", i)
+		want[string(key)] = fmt.Appendf(nil, "value-%d", i)
+	}
+
+	putCh := make(chan tbcd.Row)
+	putErr := make(chan error, 1)
+	go func() {
+		putErr <- db.MetadataStreamPut(ctx, putCh)
+	}()
+	for key, value := range want {
+		putCh <- tbcd.Row{Key: []byte(key), Value: value}
+	}
+	close(putCh)
+	if err := <-putErr; err != nil {
+		t.Fatal(err)
+	}
+
+	keysCh := make(chan []byte)
+	resultsCh := make(chan tbcd.Row)
+	getErr := make(chan error, 1)
+	go func() {
+		err := db.MetadataStreamGet(ctx, keysCh, resultsCh)
+		close(resultsCh) // sole producer: safe to close once done
+		getErr <- err
+	}()
+	go func() {
+		for key := range want {
+			keysCh <- []byte(key)
+		}
+		close(keysCh)
+	}()
+
+	got := make(map[string][]byte, rowCount)
+	for row := range resultsCh {
+		if row.Error != nil {
+			t.Fatal(row.Error)
+		}
+		got[string(row.Key)] = row.Value
+	}
+	if err := <-getErr; err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(got, want); len(diff) > 0 {
+		t.Fatalf("stream mismatch: %s", diff)
+	}
+}
+
+// TestKeystoneDBStreamUpdate exercises BlockKeystonesStreamUpdate's wind and
+// unwind paths with a small StreamBatchRows to force multiple flushes.
+func TestKeystoneDBStreamUpdate(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	home := t.TempDir()
+	t.Logf("temp: %v", home)
+
+	cfg, err := NewConfig("testnet3", home, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.StreamBatchRows = 8
+	db, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	blockhash := chainhash.Hash{0x55}
+	blkHash := chainhash.Hash{1}
+
+	const ksCount = 200
+	entries := make([]KeystoneEntry, 0, ksCount)
+	for i := 0; i < ksCount; i++ {
+		h, ks := newKeystone(&blkHash, uint32(i), uint32(i))
+		entries = append(entries, KeystoneEntry{Hash: *h, Keystone: ks})
+	}
+
+	streamEntries := func(direction int, entries []KeystoneEntry) error {
+		ch := make(chan KeystoneEntry)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- db.BlockKeystonesStreamUpdate(ctx, direction, ch, blockhash)
+		}()
+		for _, e := range entries {
+			ch <- e
+		}
+		close(ch)
+		return <-errCh
+	}
+
+	if err := streamEntries(1, entries); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		dks, err := db.BlockKeystoneByL2KeystoneAbrevHash(ctx, e.Hash)
+		if err != nil {
+			t.Fatalf("keystone not found: %v", err)
+		}
+		if !reflect.DeepEqual(e.Keystone, *dks) {
+			t.Fatalf("%v%v", spew.Sdump(e.Keystone), spew.Sdump(*dks))
+		}
+	}
+	kss, err := db.KeystonesByBlockHash(ctx, blockhash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kss) != ksCount {
+		t.Fatalf("expected %d keystones in block index, got %d", ksCount, len(kss))
+	}
+
+	if err := streamEntries(-1, entries); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if _, err := db.BlockKeystoneByL2KeystoneAbrevHash(ctx, e.Hash); !errors.Is(err, database.ErrNotFound) {
+			t.Fatalf("expected '%v', got '%v'", database.ErrNotFound, err)
+		}
+	}
+	kss, err = db.KeystonesByBlockHash(ctx, blockhash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kss) != 0 {
+		t.Fatalf("expected empty block index, got %v", spew.Sdump(kss))
+	}
+}
+
+// heapGrowth runs f and returns how much the heap grew, by GC'ing and
+// sampling runtime.MemStats before and after. It is a coarse signal, not an
+// exact accounting, but it is enough to catch an implementation that
+// materializes the whole stream in memory instead of bounding it to a few
+// batches.
+func heapGrowth(t *testing.T, f func()) int64 {
+	t.Helper()
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	return int64(after.HeapAlloc) - int64(before.HeapAlloc)
+}
+
+// TestMetadataStreamLargeScale pushes rows through
+// MetadataStreamPut/MetadataStreamGet at two scales (the larger one over
+// 1M rows), generating the rows on the fly rather than building one giant
+// slice or map, and checks that heap growth scales roughly linearly with
+// row count rather than superlinearly, which is what an implementation
+// that accumulates state proportional to the square of the stream length
+// would show.
+func TestMetadataStreamLargeScale(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	home := t.TempDir()
+	t.Logf("temp: %v", home)
+
+	cfg, err := NewConfig("testnet3", home, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	streamRows := func(rowCount int, keyPrefix string) int64 {
+		keyFor := func(i int) []byte { return fmt.Appendf(nil, "%s-key-%d", keyPrefix, i) }
+		valueFor := func(i int) []byte { return fmt.Appendf(nil, "%s-value-%d", keyPrefix, i) }
+
+		var gotCount int
+		growth := heapGrowth(t, func() {
+			putCh := make(chan tbcd.Row)
+			putErr := make(chan error, 1)
+			go func() {
+				putErr <- db.MetadataStreamPut(ctx, putCh)
+			}()
+			for i := 0; i < rowCount; i++ {
+				putCh <- tbcd.Row{Key: keyFor(i), Value: valueFor(i)}
+			}
+			close(putCh)
+			if err := <-putErr; err != nil {
+				t.Fatal(err)
+			}
+
+			keysCh := make(chan []byte)
+			resultsCh := make(chan tbcd.Row)
+			getErr := make(chan error, 1)
+			go func() {
+				err := db.MetadataStreamGet(ctx, keysCh, resultsCh)
+				close(resultsCh) // sole producer: safe to close once done
+				getErr <- err
+			}()
+			go func() {
+				for i := 0; i < rowCount; i++ {
+					keysCh <- keyFor(i)
+				}
+				close(keysCh)
+			}()
+			for row := range resultsCh {
+				if row.Error != nil {
+					t.Fatal(row.Error)
+				}
+				gotCount++
+			}
+			if err := <-getErr; err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		if gotCount != rowCount {
+			t.Fatalf("expected %d rows, got %d", rowCount, gotCount)
+		}
+		return growth
+	}
+
+	const small = 350_000
+	const large = 1_400_000 // 4x small, and over the >1M scale this feature targets
+	smallGrowth := streamRows(small, "small")
+	largeGrowth := streamRows(large, "large")
+
+	assertLinearGrowth(t, small, smallGrowth, large, largeGrowth)
+}
+
+// TestKeystoneDBStreamUpdateLargeScale pushes keystones through
+// BlockKeystonesStreamUpdate at two scales (the larger one over 1M
+// keystones), generating entries on the fly rather than building one giant
+// slice, and checks that heap growth scales roughly linearly with entry
+// count. The blockhash secondary index is accumulated in memory for the
+// duration of one block's stream (see BlockKeystonesStreamUpdate's doc
+// comment), so growth proportional to entry count is expected; what this
+// guards against is the superlinear growth the original, per-batch
+// full-index-rewrite implementation exhibited.
+func TestKeystoneDBStreamUpdateLargeScale(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	home := t.TempDir()
+	t.Logf("temp: %v", home)
+
+	cfg, err := NewConfig("testnet3", home, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	streamKeystones := func(blockhash chainhash.Hash, ksCount int) int64 {
+		blkHash := chainhash.Hash{2}
+
+		growth := heapGrowth(t, func() {
+			ch := make(chan KeystoneEntry)
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- db.BlockKeystonesStreamUpdate(ctx, 1, ch, blockhash)
+			}()
+			for i := 0; i < ksCount; i++ {
+				h, ks := newKeystone(&blkHash, uint32(i), uint32(i))
+				ch <- KeystoneEntry{Hash: *h, Keystone: ks}
+			}
+			close(ch)
+			if err := <-errCh; err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		kss, err := db.KeystonesByBlockHash(ctx, blockhash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(kss) != ksCount {
+			t.Fatalf("expected %d keystones in block index, got %d", ksCount, len(kss))
+		}
+		return growth
+	}
+
+	const small = 300_000
+	const large = 1_200_000 // 4x small, and over the >1M scale this feature targets
+	smallGrowth := streamKeystones(chainhash.Hash{0x77}, small)
+	largeGrowth := streamKeystones(chainhash.Hash{0x78}, large)
+
+	assertLinearGrowth(t, small, smallGrowth, large, largeGrowth)
+}
+
+// assertLinearGrowth fails the test if growing n from small to large caused
+// heap growth to scale more than maxSlack times worse than the linear
+// ratio large/small would predict. This is a coarse, runtime-independent
+// signal: it catches an implementation whose memory use is proportional to
+// n^2 (or worse) without depending on exact byte counts, which vary with
+// the Go allocator and the backing store.
+func assertLinearGrowth(t *testing.T, small int, smallGrowth int64, large int, largeGrowth int64) {
+	t.Helper()
+	if smallGrowth <= 0 {
+		t.Logf("small-scale run (n=%d) showed no heap growth (%d bytes); skipping ratio check", small, smallGrowth)
+		return
+	}
+
+	wantRatio := float64(large) / float64(small)
+	gotRatio := float64(largeGrowth) / float64(smallGrowth)
+	const maxSlack = 2.5 // generous slack above the expected linear ratio
+	if gotRatio > wantRatio*maxSlack {
+		t.Fatalf("heap growth scaled %.1fx (small=%d bytes, large=%d bytes) growing n %.1fx (n=%d to n=%d); looks superlinear",
+			gotRatio, smallGrowth, largeGrowth, wantRatio, small, large)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2025 Hemi Labs, Inc.
+// Use of this source code is governed by the MIT License,
+// which can be found in the LICENSE file.
+
+package level
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+
+	"github.com/hemilabs/heminetwork/database"
+	"github.com/hemilabs/heminetwork/database/tbcd"
+)
+
+// levelGetter is satisfied by *leveldb.DB, *leveldb.Snapshot and
+// *leveldb.Transaction, which lets the helpers below serve reads performed
+// directly against the database, a Snapshot or a Tx.
+type levelGetter interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+}
+
+func metadataGet(g levelGetter, key []byte) ([]byte, error) {
+	value, err := g.Get(key, nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("metadata get %x: %w", key, database.ErrNotFound)
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+func metadataBatchGet(g levelGetter, allOrNothing bool, keys [][]byte) ([]tbcd.Row, error) {
+	rows := make([]tbcd.Row, len(keys))
+	for i, key := range keys {
+		value, err := g.Get(key, nil)
+		if err != nil {
+			if !errors.Is(err, leveldb.ErrNotFound) {
+				return nil, err
+			}
+			nferr := fmt.Errorf("metadata get %x: %w", key, database.ErrNotFound)
+			if allOrNothing {
+				return nil, nferr
+			}
+			rows[i] = tbcd.Row{Key: key, Error: nferr}
+			continue
+		}
+		rows[i] = tbcd.Row{Key: key, Value: value}
+	}
+	return rows, nil
+}
+
+// MetadataGet returns the value stored at key.
+func (d *Database) MetadataGet(ctx context.Context, key []byte) ([]byte, error) {
+	return metadataGet(d.metadataDB, key)
+}
+
+// MetadataBatchGet returns the values stored at keys. If allOrNothing is
+// true, a single missing key aborts the call and returns database.ErrNotFound;
+// otherwise the corresponding tbcd.Row.Error field is populated and the
+// remaining rows are still returned.
+func (d *Database) MetadataBatchGet(ctx context.Context, allOrNothing bool, keys [][]byte) ([]tbcd.Row, error) {
+	return metadataBatchGet(d.metadataDB, allOrNothing, keys)
+}
+
+// MetadataPut stores value at key.
+func (d *Database) MetadataPut(ctx context.Context, key, value []byte) error {
+	return d.metadataDB.Put(key, value, nil)
+}
+
+// MetadataBatchPut stores rows as a single atomic batch write.
+func (d *Database) MetadataBatchPut(ctx context.Context, rows []tbcd.Row) error {
+	batch := new(leveldb.Batch)
+	for _, row := range rows {
+		batch.Put(row.Key, row.Value)
+	}
+	return d.metadataDB.Write(batch, nil)
+}
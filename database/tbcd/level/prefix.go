@@ -0,0 +1,115 @@
+// Copyright (c) 2025 Hemi Labs, Inc.
+// Use of this source code is governed by the MIT License,
+// which can be found in the LICENSE file.
+
+package level
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hemilabs/heminetwork/database"
+	"github.com/hemilabs/heminetwork/database/tbcd"
+)
+
+// MetadataStore is the metadata subset of tbcd.Database. Database satisfies
+// it directly; MetadataPrefix returns a handle that also satisfies it but
+// is scoped to a single key prefix.
+type MetadataStore interface {
+	MetadataGet(ctx context.Context, key []byte) ([]byte, error)
+	MetadataPut(ctx context.Context, key, value []byte) error
+	MetadataBatchGet(ctx context.Context, allOrNothing bool, keys [][]byte) ([]tbcd.Row, error)
+	MetadataBatchPut(ctx context.Context, rows []tbcd.Row) error
+}
+
+// prefixedMetadata is a MetadataStore that transparently namespaces every
+// key under prefix, so that two subsystems sharing the same metadata table
+// (e.g. tbc consensus state and keystone finality bookkeeping) cannot
+// observe or collide with each other's keys.
+type prefixedMetadata struct {
+	db     *Database
+	prefix []byte
+}
+
+// MetadataPrefix returns a MetadataStore scoped to prefix. Reads and writes
+// through the returned handle only ever see keys stored under prefix, and
+// callers work exclusively in terms of the unprefixed, caller-visible key.
+func (d *Database) MetadataPrefix(prefix []byte) MetadataStore {
+	return &prefixedMetadata{
+		db:     d,
+		prefix: append([]byte(nil), prefix...),
+	}
+}
+
+func (p *prefixedMetadata) prefixed(key []byte) []byte {
+	return append(append([]byte(nil), p.prefix...), key...)
+}
+
+func (p *prefixedMetadata) unprefixed(key []byte) []byte {
+	return bytes.TrimPrefix(key, p.prefix)
+}
+
+func (p *prefixedMetadata) MetadataGet(ctx context.Context, key []byte) ([]byte, error) {
+	value, err := p.db.MetadataGet(ctx, p.prefixed(key))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, fmt.Errorf("metadata get %x: %w", key, database.ErrNotFound)
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+func (p *prefixedMetadata) MetadataPut(ctx context.Context, key, value []byte) error {
+	return p.db.MetadataPut(ctx, p.prefixed(key), value)
+}
+
+func (p *prefixedMetadata) MetadataBatchGet(ctx context.Context, allOrNothing bool, keys [][]byte) ([]tbcd.Row, error) {
+	if allOrNothing {
+		// Looked up one key at a time, rather than delegated to
+		// p.db.MetadataBatchGet, so that the error for a missing key
+		// references the caller-visible key rather than the internally
+		// prefixed one. Like the underlying store, the lookup stops at the
+		// first missing key.
+		rows := make([]tbcd.Row, len(keys))
+		for i, key := range keys {
+			value, err := p.db.MetadataGet(ctx, p.prefixed(key))
+			if err != nil {
+				if errors.Is(err, database.ErrNotFound) {
+					return nil, fmt.Errorf("metadata get %x: %w", key, database.ErrNotFound)
+				}
+				return nil, err
+			}
+			rows[i] = tbcd.Row{Key: key, Value: value}
+		}
+		return rows, nil
+	}
+
+	pkeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		pkeys[i] = p.prefixed(key)
+	}
+
+	rows, err := p.db.MetadataBatchGet(ctx, false, pkeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		rows[i].Key = p.unprefixed(rows[i].Key)
+		if rows[i].Error != nil && errors.Is(rows[i].Error, database.ErrNotFound) {
+			rows[i].Error = fmt.Errorf("metadata get %x: %w", rows[i].Key, database.ErrNotFound)
+		}
+	}
+	return rows, nil
+}
+
+func (p *prefixedMetadata) MetadataBatchPut(ctx context.Context, rows []tbcd.Row) error {
+	prows := make([]tbcd.Row, len(rows))
+	for i, row := range rows {
+		prows[i] = tbcd.Row{Key: p.prefixed(row.Key), Value: row.Value}
+	}
+	return p.db.MetadataBatchPut(ctx, prows)
+}
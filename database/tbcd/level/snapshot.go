@@ -0,0 +1,85 @@
+// Copyright (c) 2025 Hemi Labs, Inc.
+// Use of this source code is governed by the MIT License,
+// which can be found in the LICENSE file.
+
+package level
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/hemilabs/heminetwork/database/tbcd"
+)
+
+// Snapshot is a read-consistent, point-in-time view of the database. It is
+// backed by goleveldb's own snapshot mechanism, so it does not block
+// concurrent writers: a Snapshot taken before a keystone unwind continues to
+// observe the pre-unwind keystones for as long as it is held open.
+//
+// Snapshot must be released with Release once the caller is done with it.
+type Snapshot struct {
+	metadata *leveldb.Snapshot
+	keystone *leveldb.Snapshot
+}
+
+// Snapshot returns a consistent, point-in-time view of the database.
+func (d *Database) Snapshot(ctx context.Context) (*Snapshot, error) {
+	md, err := d.metadataDB.GetSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("metadata snapshot: %w", err)
+	}
+	ks, err := d.keystoneDB.GetSnapshot()
+	if err != nil {
+		md.Release()
+		return nil, fmt.Errorf("keystone snapshot: %w", err)
+	}
+	return &Snapshot{metadata: md, keystone: ks}, nil
+}
+
+// Release releases the resources held by the snapshot. The snapshot must
+// not be used after Release is called.
+func (s *Snapshot) Release() {
+	s.metadata.Release()
+	s.keystone.Release()
+}
+
+// MetadataGet returns the value stored at key as of when the snapshot was
+// taken.
+func (s *Snapshot) MetadataGet(ctx context.Context, key []byte) ([]byte, error) {
+	return metadataGet(s.metadata, key)
+}
+
+// MetadataBatchGet returns the values stored at keys as of when the
+// snapshot was taken. See Database.MetadataBatchGet for allOrNothing
+// semantics.
+func (s *Snapshot) MetadataBatchGet(ctx context.Context, allOrNothing bool, keys [][]byte) ([]tbcd.Row, error) {
+	return metadataBatchGet(s.metadata, allOrNothing, keys)
+}
+
+// BlockKeystoneByL2KeystoneAbrevHash returns the keystone stored under the
+// given abbreviated L2 keystone hash as of when the snapshot was taken.
+func (s *Snapshot) BlockKeystoneByL2KeystoneAbrevHash(ctx context.Context, abrvHash chainhash.Hash) (*tbcd.Keystone, error) {
+	return keystoneByL2KeystoneAbrevHash(s.keystone, abrvHash)
+}
+
+// MetadataIterator returns an iterator over the metadata keyspace as it
+// existed when the snapshot was taken. The returned iterator must be
+// released by the caller.
+func (s *Snapshot) MetadataIterator(slice *util.Range) iterator.Iterator {
+	return s.metadata.NewIterator(slice, nil)
+}
+
+// KeystoneIterator returns an iterator over the raw keystone keyspace as it
+// existed when the snapshot was taken. Keys are returned as stored: primary
+// rows are keystonePrimaryPrefix followed by the abbreviated keystone hash
+// (value: encoded tbcd.Keystone), and the blockhash secondary index rows
+// (blockIndexPrefix) are included unless slice excludes them. The returned
+// iterator must be released by the caller.
+func (s *Snapshot) KeystoneIterator(slice *util.Range) iterator.Iterator {
+	return s.keystone.NewIterator(slice, nil)
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2025 Hemi Labs, Inc.
+// Use of this source code is governed by the MIT License,
+// which can be found in the LICENSE file.
+
+package level
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/hemilabs/heminetwork/database"
+	"github.com/hemilabs/heminetwork/database/tbcd"
+)
+
+// KeystoneEntry is one row of a BlockKeystonesStreamUpdate stream: the
+// abbreviated L2 keystone hash and the keystone stored under it.
+type KeystoneEntry struct {
+	Hash     chainhash.Hash
+	Keystone tbcd.Keystone
+}
+
+// MetadataStreamPut consumes rows from ch and writes them to the metadata
+// database, coalescing them into leveldb batches of at most
+// cfg.StreamBatchBytes or cfg.StreamBatchRows (whichever is hit first) so
+// that streaming tens of millions of rows does not require holding them all
+// in memory at once. On error, or if ctx is canceled, it stops consuming ch
+// and returns the first error observed; callers should stop sending on ch
+// in that case to avoid blocking forever.
+func (d *Database) MetadataStreamPut(ctx context.Context, ch <-chan tbcd.Row) error {
+	batch := new(leveldb.Batch)
+	batchBytes, batchRows := 0, 0
+
+	flush := func() error {
+		if batchRows == 0 {
+			return nil
+		}
+		if err := d.metadataDB.Write(batch, nil); err != nil {
+			return fmt.Errorf("flush metadata batch: %w", err)
+		}
+		batch.Reset()
+		batchBytes, batchRows = 0, 0
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case row, ok := <-ch:
+			if !ok {
+				return flush()
+			}
+			batch.Put(row.Key, row.Value)
+			batchBytes += len(row.Key) + len(row.Value)
+			batchRows++
+			if batchBytes >= d.cfg.StreamBatchBytes || batchRows >= d.cfg.StreamBatchRows {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// MetadataStreamGet reads keys from keys, looks each one up, and sends the
+// resulting tbcd.Row to results. Sends to results are backpressured: a slow
+// consumer of results stalls reads from keys rather than buffering lookups
+// in memory. On error, or if ctx is canceled, it stops and returns the
+// first error observed; callers should stop sending on keys and draining
+// results in that case.
+func (d *Database) MetadataStreamGet(ctx context.Context, keys <-chan []byte, results chan<- tbcd.Row) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+
+			row := tbcd.Row{Key: key}
+			value, err := metadataGet(d.metadataDB, key)
+			if err != nil {
+				// A missing key is reported in the row; any other
+				// lookup error ends the stream.
+				if !errors.Is(err, database.ErrNotFound) {
+					return err
+				}
+				row.Error = err
+			} else {
+				row.Value = value
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case results <- row:
+			}
+		}
+	}
+}
+
+// BlockKeystonesStreamUpdate consumes KeystoneEntry values from ch and
+// winds (direction 1) or unwinds (direction -1) them against the keystone
+// database and its blockhash secondary index, coalescing writes into
+// leveldb batches of at most cfg.StreamBatchBytes or cfg.StreamBatchRows
+// (whichever is hit first). On error, or if ctx is canceled, it stops
+// consuming ch and returns the first error observed.
+//
+// The primary keystone rows are durable at each batch boundary as they are
+// flushed. The blockhash secondary index, however, is accumulated in memory
+// for the whole stream (every entry targets the same blockhash, so there is
+// one index row to maintain) and is only written once, after the last entry
+// has been applied, to avoid re-serializing and rewriting the whole,
+// still-growing index row at every batch boundary. This bounds the
+// per-block index memory to the same O(entries for this block) that
+// BlockKeystoneUpdate's kssMap argument already requires, but it means that
+// if the stream is interrupted (ctx canceled, an error, or a crash) after
+// some batches of primary rows have flushed but before the stream
+// completes, the index will not reflect those rows; callers that need the
+// index to stay consistent with a partial import must not treat a
+// non-nil-returning stream as safe to resume and should instead retry the
+// whole block from scratch.
+func (d *Database) BlockKeystonesStreamUpdate(ctx context.Context, direction int, ch <-chan KeystoneEntry, blockhash chainhash.Hash) error {
+	if direction != 1 && direction != -1 {
+		return fmt.Errorf("invalid direction: %v", direction)
+	}
+
+	bi, err := loadBlockIndex(d.keystoneDB, blockhash)
+	if err != nil {
+		return fmt.Errorf("load block index: %w", err)
+	}
+
+	batch := new(leveldb.Batch)
+	batchBytes, batchRows := 0, 0
+
+	flush := func() error {
+		if batchRows == 0 {
+			return nil
+		}
+		if err := d.keystoneDB.Write(batch, nil); err != nil {
+			return fmt.Errorf("flush keystone batch: %w", err)
+		}
+		batch.Reset()
+		batchBytes, batchRows = 0, 0
+		return nil
+	}
+
+	flushIndex := func() error {
+		ib := new(leveldb.Batch)
+		bi.flush(ib)
+		if err := d.keystoneDB.Write(ib, nil); err != nil {
+			return fmt.Errorf("flush block index: %w", err)
+		}
+		return nil
+	}
+
+	applyEntry := func(entry KeystoneEntry) error {
+		switch direction {
+		case 1:
+			batch.Put(primaryKey(entry.Hash), encodeKeystoneToSlice(entry.Keystone))
+			batchBytes += chainhash.HashSize + len(entry.Keystone.AbbreviatedKeystone)
+			bi.add(entry.Hash)
+		case -1:
+			existing, err := d.keystoneDB.Get(primaryKey(entry.Hash), nil)
+			if err != nil {
+				if errors.Is(err, leveldb.ErrNotFound) {
+					return nil
+				}
+				return err
+			}
+			if stored := decodeKeystone(existing); !reflect.DeepEqual(stored, entry.Keystone) {
+				return nil
+			}
+			batch.Delete(primaryKey(entry.Hash))
+			bi.remove(entry.Hash)
+		}
+		batchRows++
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case entry, ok := <-ch:
+			if !ok {
+				if err := flush(); err != nil {
+					return err
+				}
+				return flushIndex()
+			}
+			if err := applyEntry(entry); err != nil {
+				return err
+			}
+			if batchBytes >= d.cfg.StreamBatchBytes || batchRows >= d.cfg.StreamBatchRows {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
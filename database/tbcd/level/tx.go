@@ -0,0 +1,119 @@
+// Copyright (c) 2025 Hemi Labs, Inc.
+// Use of this source code is governed by the MIT License,
+// which can be found in the LICENSE file.
+
+package level
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/hemilabs/heminetwork/database/tbcd"
+)
+
+// Tx is an atomic batch of writes against the database. Reads performed
+// through a Tx observe the Tx's own uncommitted writes; nothing is visible
+// to other readers until Commit succeeds, and Rollback discards everything
+// written through the Tx.
+//
+// A Tx must be terminated with exactly one call to Commit or Rollback.
+type Tx struct {
+	metadata *leveldb.Transaction
+	keystone *leveldb.Transaction
+	done     bool
+}
+
+// Transaction opens a new atomic transaction against the database.
+func (d *Database) Transaction(ctx context.Context) (*Tx, error) {
+	mtx, err := d.metadataDB.OpenTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("open metadata transaction: %w", err)
+	}
+	ktx, err := d.keystoneDB.OpenTransaction()
+	if err != nil {
+		mtx.Discard()
+		return nil, fmt.Errorf("open keystone transaction: %w", err)
+	}
+	return &Tx{metadata: mtx, keystone: ktx}, nil
+}
+
+// MetadataGet returns the value stored at key, including any not-yet-committed
+// write made earlier in this Tx.
+func (tx *Tx) MetadataGet(ctx context.Context, key []byte) ([]byte, error) {
+	return metadataGet(tx.metadata, key)
+}
+
+// MetadataBatchGet returns the values stored at keys, including any
+// not-yet-committed writes made earlier in this Tx. See
+// Database.MetadataBatchGet for allOrNothing semantics.
+func (tx *Tx) MetadataBatchGet(ctx context.Context, allOrNothing bool, keys [][]byte) ([]tbcd.Row, error) {
+	return metadataBatchGet(tx.metadata, allOrNothing, keys)
+}
+
+// MetadataPut stores value at key within the Tx.
+func (tx *Tx) MetadataPut(ctx context.Context, key, value []byte) error {
+	return tx.metadata.Put(key, value, nil)
+}
+
+// MetadataBatchPut stores rows as a single batch write within the Tx.
+func (tx *Tx) MetadataBatchPut(ctx context.Context, rows []tbcd.Row) error {
+	batch := new(leveldb.Batch)
+	for _, row := range rows {
+		batch.Put(row.Key, row.Value)
+	}
+	return tx.metadata.Write(batch, nil)
+}
+
+// BlockKeystoneByL2KeystoneAbrevHash returns the keystone stored under the
+// given abbreviated L2 keystone hash, including any not-yet-committed write
+// made earlier in this Tx.
+func (tx *Tx) BlockKeystoneByL2KeystoneAbrevHash(ctx context.Context, abrvHash chainhash.Hash) (*tbcd.Keystone, error) {
+	return keystoneByL2KeystoneAbrevHash(tx.keystone, abrvHash)
+}
+
+// BlockKeystoneUpdate winds or unwinds kssMap within the Tx. See
+// Database.BlockKeystoneUpdate for direction and compare-and-delete
+// semantics.
+func (tx *Tx) BlockKeystoneUpdate(ctx context.Context, direction int, kssMap map[chainhash.Hash]tbcd.Keystone, blockhash chainhash.Hash) error {
+	return blockKeystoneUpdate(tx.keystone, direction, kssMap, blockhash)
+}
+
+// Commit atomically commits all writes performed through the Tx.
+//
+// Note this is all-or-nothing from the perspective of each underlying
+// store, but the database is split across two independent goleveldb
+// instances (metadata and keystones), so a failure committing the second
+// store cannot roll back the first. In practice this can only happen on an
+// underlying I/O error, at which point the process should treat the
+// database as needing recovery rather than retry the Tx.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return errors.New("transaction already closed")
+	}
+	tx.done = true
+
+	if err := tx.keystone.Commit(); err != nil {
+		tx.metadata.Discard()
+		return fmt.Errorf("commit keystone transaction: %w", err)
+	}
+	if err := tx.metadata.Commit(); err != nil {
+		tx.metadata.Discard()
+		return fmt.Errorf("commit metadata transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback discards all writes performed through the Tx. Rollback is a
+// no-op if the Tx was already committed or rolled back.
+func (tx *Tx) Rollback() {
+	if tx.done {
+		return
+	}
+	tx.done = true
+	tx.keystone.Discard()
+	tx.metadata.Discard()
+}